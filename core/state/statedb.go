@@ -0,0 +1,221 @@
+package state
+
+import (
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Database is the backing store a StateDB is created against. Real
+// go-ethereum's Database wraps a trie database and snapshot layer; this
+// package only needs an opaque handle, since every StateDB in this repo is
+// the self-contained in-memory implementation below.
+type Database interface{}
+
+type memoryDatabase struct{}
+
+// NewDatabaseForTesting returns a throwaway in-memory Database, suitable for
+// unit tests and fuzzing.
+func NewDatabaseForTesting() Database {
+	return &memoryDatabase{}
+}
+
+// accountState holds one account's code and storage.
+type accountState struct {
+	exists  bool
+	code    []byte
+	storage map[common.Hash]common.Hash
+}
+
+// journalEntry undoes a single mutation when a snapshot is reverted, the
+// same pattern go-ethereum's real journal uses, just with closures standing
+// in for the real journal's per-kind change structs.
+type journalEntry func()
+
+// StateDB is a minimal, self-contained account/storage store: account
+// creation, code, storage reads/writes, EIP-2929 access lists, and
+// snapshot/revert. It implements just enough of go-ethereum's real StateDB
+// surface for core/vm's interpreter, and this package's warm storage cache,
+// to run against.
+type StateDB struct {
+	db Database
+
+	accounts map[common.Address]*accountState
+
+	addressAccessList map[common.Address]bool
+	slotAccessList    map[common.Address]map[common.Hash]bool
+
+	journal []journalEntry
+
+	// warmStorage is the block-scoped SLOAD cache (warm_storage_cache.go).
+	// It is nil until EnableWarmStorageCache is called.
+	warmStorage *warmStorageCache
+}
+
+// New creates an empty StateDB. root is accepted to match the shape of a
+// real trie-backed constructor; this in-memory implementation always starts
+// empty regardless of which root is named.
+func New(root common.Hash, db Database) (*StateDB, error) {
+	return &StateDB{
+		db:                db,
+		accounts:          make(map[common.Address]*accountState),
+		addressAccessList: make(map[common.Address]bool),
+		slotAccessList:    make(map[common.Address]map[common.Hash]bool),
+	}, nil
+}
+
+func (s *StateDB) account(addr common.Address) *accountState {
+	a, ok := s.accounts[addr]
+	if !ok {
+		a = &accountState{storage: make(map[common.Hash]common.Hash)}
+		s.accounts[addr] = a
+	}
+	return a
+}
+
+// CreateAccount marks addr as existing.
+func (s *StateDB) CreateAccount(addr common.Address) {
+	a := s.account(addr)
+	existed := a.exists
+	a.exists = true
+	s.journal = append(s.journal, func() { a.exists = existed })
+}
+
+// SetCode sets addr's code.
+func (s *StateDB) SetCode(addr common.Address, code []byte) {
+	a := s.account(addr)
+	prev := a.code
+	a.code = code
+	s.journal = append(s.journal, func() { a.code = prev })
+}
+
+// GetCode returns addr's code, or nil if the account doesn't exist.
+func (s *StateDB) GetCode(addr common.Address) []byte {
+	a, ok := s.accounts[addr]
+	if !ok {
+		return nil
+	}
+	return a.code
+}
+
+// getCommittedState reads the authoritative, uncached storage value.
+func (s *StateDB) getCommittedState(addr common.Address, key common.Hash) common.Hash {
+	a, ok := s.accounts[addr]
+	if !ok {
+		return common.Hash{}
+	}
+	return a.storage[key]
+}
+
+// GetState returns addr's value at key, serving it from the warm storage
+// cache when one is enabled and already holds it. A cache miss populates
+// the cache with the committed value it just read, and that population is
+// journaled like any other mutation: if the snapshot this read happened
+// under is later reverted, the cache entry is forgotten along with it, so a
+// read that only ever observed a since-reverted write can't outlive that
+// revert and leak a stale value into a later read.
+func (s *StateDB) GetState(addr common.Address, key common.Hash) common.Hash {
+	if v, ok := s.WarmStorage(addr, key); ok {
+		return v
+	}
+	v := s.getCommittedState(addr, key)
+	s.noteWarmStorageRead(addr, key, v)
+	s.journal = append(s.journal, func() { s.forgetWarmStorageRead(addr, key) })
+	return v
+}
+
+// SetState writes addr's value at key and invalidates any warm storage
+// cache entry for it, so a later read — in this transaction or, since the
+// cache survives across transactions, a later one in the same block — never
+// observes the value this write is replacing.
+func (s *StateDB) SetState(addr common.Address, key, value common.Hash) {
+	a := s.account(addr)
+	prev := a.storage[key]
+	a.storage[key] = value
+	s.journal = append(s.journal, func() { a.storage[key] = prev })
+	s.noteWarmStorageWrite(addr, key)
+}
+
+// SlotInAccessList reports whether addr, and separately slot within addr,
+// are already in the EIP-2929 access list.
+func (s *StateDB) SlotInAccessList(addr common.Address, slot common.Hash) (addressOk, slotOk bool) {
+	addressOk = s.addressAccessList[addr]
+	slots, ok := s.slotAccessList[addr]
+	if !ok {
+		return addressOk, false
+	}
+	return addressOk, slots[slot]
+}
+
+// AddSlotToAccessList marks addr and slot as warm.
+func (s *StateDB) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	wasAddr := s.addressAccessList[addr]
+	s.addressAccessList[addr] = true
+
+	slots, ok := s.slotAccessList[addr]
+	if !ok {
+		slots = make(map[common.Hash]bool)
+		s.slotAccessList[addr] = slots
+	}
+	wasSlot := slots[slot]
+	slots[slot] = true
+
+	s.journal = append(s.journal, func() {
+		s.addressAccessList[addr] = wasAddr
+		slots[slot] = wasSlot
+	})
+}
+
+// Snapshot returns an identifier for the current journal position, to be
+// passed to a later RevertToSnapshot.
+func (s *StateDB) Snapshot() int {
+	return len(s.journal)
+}
+
+// RevertToSnapshot undoes every mutation recorded since id was returned by
+// Snapshot, in reverse order.
+func (s *StateDB) RevertToSnapshot(id int) {
+	for i := len(s.journal) - 1; i >= id; i-- {
+		s.journal[i]()
+	}
+	s.journal = s.journal[:id]
+}
+
+// IntermediateRoot returns a deterministic digest over every existing
+// account's code and non-zero storage. It stands in for a real Merkle root:
+// tests and the core/vm fuzz harness only need it to be a faithful,
+// order-independent summary of state they can compare for equality, not an
+// actual trie root.
+func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
+	addrs := make([]common.Address, 0, len(s.accounts))
+	for addr := range s.accounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Hex() < addrs[j].Hex() })
+
+	var buf []byte
+	for _, addr := range addrs {
+		a := s.accounts[addr]
+		if !a.exists {
+			continue
+		}
+		buf = append(buf, addr.Bytes()...)
+		buf = append(buf, a.code...)
+
+		keys := make([]common.Hash, 0, len(a.storage))
+		for k := range a.storage {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Hex() < keys[j].Hex() })
+		for _, k := range keys {
+			v := a.storage[k]
+			if v == (common.Hash{}) {
+				continue
+			}
+			buf = append(buf, k.Bytes()...)
+			buf = append(buf, v.Bytes()...)
+		}
+	}
+	return crypto.Keccak256Hash(buf)
+}
@@ -0,0 +1,168 @@
+package state
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	warmStorageHitMeter      = metrics.NewRegisteredMeter("state/warmstorage/hits", nil)
+	warmStorageMissMeter     = metrics.NewRegisteredMeter("state/warmstorage/misses", nil)
+	warmStorageEvictionMeter = metrics.NewRegisteredMeter("state/warmstorage/evictions", nil)
+)
+
+// defaultWarmStorageCacheSize is used when a StateDB enables the warm
+// storage cache without specifying an explicit size.
+const defaultWarmStorageCacheSize = 4096
+
+// warmStorageKey identifies a single storage slot belonging to an account,
+// the unit of granularity for both caching and invalidation.
+type warmStorageKey struct {
+	addr common.Address
+	slot common.Hash
+}
+
+// warmStorageCache is an LRU of storage slot values attached to a StateDB.
+// Unlike core/vm's per-EVM sloadCache, which only lives for the duration of
+// a single transaction, this cache is created once per StateDB and so
+// survives across every transaction processed against that StateDB, i.e.
+// for the lifetime of one block. A later transaction in the same block that
+// reads a slot an earlier transaction already warmed skips the underlying
+// trie/snapshot read entirely.
+//
+// Invalidation happens eagerly: a write through SetState removes the slot
+// from the cache immediately, rather than waiting for the transaction or
+// block to finish. Eager invalidation alone does not cover reverts: a read
+// inside a nested call can cache a value that call wrote, and reverting the
+// call restores the older committed value. StateDB.GetState therefore
+// journals every population it makes (see forgetWarmStorageRead), so
+// RevertToSnapshot removes such an entry along with the write.
+type warmStorageCache struct {
+	mu    sync.Mutex
+	cache lru.BasicLRU[warmStorageKey, common.Hash]
+}
+
+// newWarmStorageCache creates a cache holding up to size entries.
+func newWarmStorageCache(size int) *warmStorageCache {
+	if size <= 0 {
+		size = defaultWarmStorageCacheSize
+	}
+	return &warmStorageCache{cache: lru.NewBasicLRU[warmStorageKey, common.Hash](size)}
+}
+
+// get returns the cached value for (addr, slot), if present.
+func (w *warmStorageCache) get(addr common.Address, slot common.Hash) (common.Hash, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	v, ok := w.cache.Get(warmStorageKey{addr, slot})
+	if ok {
+		warmStorageHitMeter.Mark(1)
+	} else {
+		warmStorageMissMeter.Mark(1)
+	}
+	return v, ok
+}
+
+// set records a freshly read value, evicting the least recently used entry
+// if the cache is full.
+func (w *warmStorageCache) set(addr common.Address, slot, value common.Hash) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cache.Add(warmStorageKey{addr, slot}, value) {
+		warmStorageEvictionMeter.Mark(1)
+	}
+}
+
+// invalidate drops any cached entry for (addr, slot). Called from SetState
+// so the next read of that slot, in this transaction or a later one in the
+// same block, goes back to the authoritative trie/snapshot read.
+func (w *warmStorageCache) invalidate(addr common.Address, slot common.Hash) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cache.Remove(warmStorageKey{addr, slot})
+}
+
+// reset discards every cached entry. Called at block boundaries: either
+// when a StateDB is reused for a new block via ResetWarmStorageCache, or simply
+// left to happen implicitly when a fresh StateDB is constructed for the
+// next block.
+func (w *warmStorageCache) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cache.Purge()
+}
+
+// EnableWarmStorageCache attaches a block-scoped SLOAD cache of the given
+// size to s. size <= 0 uses defaultWarmStorageCacheSize. It is a no-op if
+// the cache is already enabled, whatever size is requested: the existing
+// cache and its size are kept.
+func (s *StateDB) EnableWarmStorageCache(size int) {
+	if s.warmStorage != nil {
+		return
+	}
+	s.warmStorage = newWarmStorageCache(size)
+}
+
+// WarmStorage returns the cached value for (addr, slot) and whether it was
+// present. StateDB.GetState consults it before reading committed storage,
+// so every SLOAD that reaches GetState is served from it when possible. It
+// returns false whenever the cache hasn't been enabled via
+// EnableWarmStorageCache.
+func (s *StateDB) WarmStorage(addr common.Address, slot common.Hash) (common.Hash, bool) {
+	if s.warmStorage == nil {
+		return common.Hash{}, false
+	}
+	return s.warmStorage.get(addr, slot)
+}
+
+// noteWarmStorageRead records a value just loaded from the trie/snapshot so
+// future reads of the same slot within this block can skip straight to the
+// cache. Call sites that read storage outside of SetState (e.g. a warm
+// StateDB.GetState miss) should call this after loading the canonical
+// value.
+func (s *StateDB) noteWarmStorageRead(addr common.Address, slot, value common.Hash) {
+	if s.warmStorage == nil {
+		return
+	}
+	s.warmStorage.set(addr, slot, value)
+}
+
+// noteWarmStorageWrite invalidates any cached value for (addr, slot). It is
+// called from SetState on every write, committed or not. The write adds no
+// journal entry for the cache; reverts are covered by the journal entry
+// GetState adds for each population (see forgetWarmStorageRead).
+func (s *StateDB) noteWarmStorageWrite(addr common.Address, slot common.Hash) {
+	if s.warmStorage == nil {
+		return
+	}
+	s.warmStorage.invalidate(addr, slot)
+}
+
+// forgetWarmStorageRead undoes the cache population GetState performs on a
+// miss. It is the journal counterpart to noteWarmStorageRead: GetState
+// appends a journal entry calling this alongside every population, so that
+// RevertToSnapshot unwinds a cached read the same way it unwinds a storage
+// write. Without this, a read that only ever observed a value written by a
+// since-reverted nested call would keep serving that value out of the
+// cache, even though the committed storage it read from has gone back to
+// what it was before that call.
+func (s *StateDB) forgetWarmStorageRead(addr common.Address, slot common.Hash) {
+	if s.warmStorage == nil {
+		return
+	}
+	s.warmStorage.invalidate(addr, slot)
+}
+
+// ResetWarmStorageCache flushes the block-scoped SLOAD cache. A caller that
+// reuses a StateDB instance across blocks calls this at the start of each
+// new block, so a slot's value from the previous block can never be served
+// into the new one.
+func (s *StateDB) ResetWarmStorageCache() {
+	if s.warmStorage == nil {
+		return
+	}
+	s.warmStorage.reset()
+}
@@ -0,0 +1,150 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// newWarmStorageTestStateDB 输入: testing.T；输出: 已启用 warm storage 缓存的 *StateDB；
+// 作用: 为多笔交易共享同一缓存的场景提供最小化的 StateDB。
+func newWarmStorageTestStateDB(t *testing.T) *StateDB {
+	t.Helper()
+	caching := NewDatabaseForTesting()
+	statedb, err := New(types.EmptyRootHash, caching)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	statedb.EnableWarmStorageCache(0)
+	return statedb
+}
+
+// TestWarmStorageCacheBenefitsLaterTransaction 输入: testing.T；输出: 无；
+// 作用: 模拟同一区块内的两笔交易，完全通过真实的 GetState/SetState 调用驱动：
+// 第一笔交易的 GetState 是冷路径，会把结果记入缓存；随后直接改写底层已提交
+// 存储（绕开 SetState，因此不会触发失效），第二笔交易的 GetState 若仍然
+// 观察到旧值，就证明它确实是从 warm storage 缓存提供的，而不是恰好读到了
+// 相同的底层值。
+func TestWarmStorageCacheBenefitsLaterTransaction(t *testing.T) {
+	statedb := newWarmStorageTestStateDB(t)
+
+	addr := common.HexToAddress("0xb00000000000000000000000000000000000001")
+	slot := common.HexToHash("0x01")
+	value := common.HexToHash("0x2a")
+
+	statedb.CreateAccount(addr)
+	statedb.SetState(addr, slot, value)
+
+	if got := statedb.GetState(addr, slot); got != value {
+		t.Fatalf("expected first transaction's GetState to observe %x, got %x", value.Bytes(), got.Bytes())
+	}
+	if cached, ok := statedb.WarmStorage(addr, slot); !ok || cached != value {
+		t.Fatalf("expected the first transaction's GetState to warm the cache with %x", value.Bytes())
+	}
+
+	// 绕开 SetState 直接改写已提交存储，单独隔离出缓存命中这一个变量。
+	statedb.accounts[addr].storage[slot] = common.HexToHash("0xff")
+
+	if got := statedb.GetState(addr, slot); got != value {
+		t.Fatalf("expected second transaction's GetState to be served from the warm storage cache (%x), got %x", value.Bytes(), got.Bytes())
+	}
+}
+
+// TestWarmStorageCacheInvalidatedBySstore 输入: testing.T；输出: 无；
+// 作用: 验证一笔交易中真实的 SetState 调用会使缓存失效，确保后续交易的
+// GetState 不会读到旧值。
+func TestWarmStorageCacheInvalidatedBySstore(t *testing.T) {
+	statedb := newWarmStorageTestStateDB(t)
+
+	addr := common.HexToAddress("0xb00000000000000000000000000000000000002")
+	slot := common.HexToHash("0x02")
+	initial := common.HexToHash("0x03")
+	updated := common.HexToHash("0x04")
+
+	statedb.CreateAccount(addr)
+	statedb.SetState(addr, slot, initial)
+
+	// 第一笔交易：真实调用 GetState，记入缓存。
+	if got := statedb.GetState(addr, slot); got != initial {
+		t.Fatalf("expected first GetState to observe %x, got %x", initial.Bytes(), got.Bytes())
+	}
+	if _, ok := statedb.WarmStorage(addr, slot); !ok {
+		t.Fatalf("expected first GetState to warm the cache")
+	}
+
+	// 第二笔交易中的 SSTORE：真实调用 SetState。
+	statedb.SetState(addr, slot, updated)
+
+	if _, ok := statedb.WarmStorage(addr, slot); ok {
+		t.Fatalf("expected SetState to invalidate the warm storage cache entry")
+	}
+	if got := statedb.GetState(addr, slot); got != updated {
+		t.Fatalf("expected a later GetState to observe the updated value %x, got %x", updated.Bytes(), got.Bytes())
+	}
+}
+
+// TestWarmStorageCacheResetAtBlockBoundary 输入: testing.T；输出: 无；
+// 作用: 验证 ResetWarmStorageCache 会清空由真实 GetState 调用填充的条目，
+// 模拟新区块开始时的失效。
+func TestWarmStorageCacheResetAtBlockBoundary(t *testing.T) {
+	statedb := newWarmStorageTestStateDB(t)
+
+	addr := common.HexToAddress("0xb00000000000000000000000000000000000003")
+	slot := common.HexToHash("0x05")
+	value := common.HexToHash("0x06")
+
+	statedb.CreateAccount(addr)
+	statedb.SetState(addr, slot, value)
+	statedb.GetState(addr, slot) // warms the cache the same way a transaction would
+
+	if _, ok := statedb.WarmStorage(addr, slot); !ok {
+		t.Fatalf("expected entry to be cached before reset")
+	}
+
+	statedb.ResetWarmStorageCache()
+
+	if _, ok := statedb.WarmStorage(addr, slot); ok {
+		t.Fatalf("expected cache to be empty after a block-boundary reset")
+	}
+}
+
+// TestWarmStorageCacheNotPoisonedByRevertedRead 输入: testing.T；输出: 无；
+// 作用: 复现并验证嵌套调用回滚时缓存不会泄漏即将被撤销的写入。外层先 SLOAD
+// 把 v0 记入缓存；随后在一个 snapshot 之后的嵌套调用中 SSTORE 写入 v1（使缓存
+// 失效），再次 SLOAD 使缓存重新记入尚未提交的 v1；嵌套调用回滚后，已提交存储
+// 恢复为 v0，若缓存未随 journal 一起撤销，之后的 GetState 就会读到已回滚的
+// v1，而不是正确的 v0。
+func TestWarmStorageCacheNotPoisonedByRevertedRead(t *testing.T) {
+	statedb := newWarmStorageTestStateDB(t)
+
+	addr := common.HexToAddress("0xb00000000000000000000000000000000000004")
+	slot := common.HexToHash("0x07")
+	v0 := common.HexToHash("0x08")
+	v1 := common.HexToHash("0x09")
+
+	statedb.CreateAccount(addr)
+	statedb.SetState(addr, slot, v0)
+
+	// Outer frame: a SLOAD warms the cache with v0, the value still in
+	// effect once the nested call below reverts.
+	if got := statedb.GetState(addr, slot); got != v0 {
+		t.Fatalf("expected outer GetState to observe %x, got %x", v0.Bytes(), got.Bytes())
+	}
+
+	// Nested frame: SSTORE invalidates v0 and writes v1, then a second
+	// SLOAD re-caches the not-yet-committed v1.
+	snapshot := statedb.Snapshot()
+	statedb.SetState(addr, slot, v1)
+	if got := statedb.GetState(addr, slot); got != v1 {
+		t.Fatalf("expected nested GetState to observe %x, got %x", v1.Bytes(), got.Bytes())
+	}
+
+	// The nested frame reverts: committed storage goes back to v0, and the
+	// warm storage cache must go back with it.
+	statedb.RevertToSnapshot(snapshot)
+
+	if got := statedb.GetState(addr, slot); got != v0 {
+		t.Fatalf("expected GetState after revert to observe %x, got %x (a reverted write leaked through the warm storage cache)", v0.Bytes(), got.Bytes())
+	}
+}
@@ -0,0 +1,50 @@
+// Package tracing defines the hook points core/vm invokes to let external
+// tracers observe EVM execution without being compiled into the hot path
+// unless a hook is actually set.
+package tracing
+
+import "github.com/ethereum/go-ethereum/common"
+
+// Hooks is the set of optional callbacks a tracer may implement. A nil
+// field means that event is simply not reported; core/vm checks each hook
+// for nil before calling it.
+type Hooks struct {
+	// OnStorageAccess reports a single storage read: which slot of which
+	// account was read, the value returned, and where it was served from
+	// (cold state, warm access-list, or the SLOAD cache). It fires once
+	// per SLOAD, whether or not the read was satisfied from a cache.
+	OnStorageAccess func(addr common.Address, slot, value common.Hash, source StorageAccessSource)
+}
+
+// StorageAccessSource classifies where a storage read reported through
+// OnStorageAccess was served from. It lives here, rather than in core/vm,
+// so a tracer can depend on this package alone without importing core/vm.
+type StorageAccessSource uint8
+
+const (
+	// StorageAccessCold is a read of a slot not yet in the access list;
+	// it is charged the cold SLOAD gas cost.
+	StorageAccessCold StorageAccessSource = iota
+	// StorageAccessWarm is a read of a slot already in the access list
+	// but not present in the SLOAD cache; it is charged the warm cost.
+	StorageAccessWarm
+	// StorageAccessCache is a read served from the per-frame SLOAD cache.
+	// It is charged what the same read costs without the cache: the warm
+	// cost, or the cold cost when the storage prefetcher put the entry
+	// there and the slot is not yet in the access list.
+	StorageAccessCache
+)
+
+// String implements fmt.Stringer.
+func (s StorageAccessSource) String() string {
+	switch s {
+	case StorageAccessCold:
+		return "cold"
+	case StorageAccessWarm:
+		return "warm"
+	case StorageAccessCache:
+		return "cache"
+	default:
+		return "unknown"
+	}
+}
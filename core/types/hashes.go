@@ -0,0 +1,7 @@
+package types
+
+import "github.com/ethereum/go-ethereum/common"
+
+// EmptyRootHash is the known root hash of an empty trie, used as the
+// starting state root for a brand-new StateDB.
+var EmptyRootHash = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
@@ -0,0 +1,58 @@
+package vm
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// bitvec is a jumpdest-analysis bitmap, one bit per code byte marking
+// whether it's a valid JUMPDEST. This package's reduced instruction set has
+// no JUMP/JUMPI, so the bitmap is never actually consulted; it exists purely
+// so Contract's constructor signature matches the shared per-EVM analysis
+// cache (evm.jumpDests) the rest of go-ethereum keys by code hash.
+type bitvec []byte
+
+// Contract represents one call frame's executable context: whose storage
+// opcodes read and write, whose code is running, and how much gas remains.
+type Contract struct {
+	Caller  common.Address
+	Address common.Address
+
+	Value *uint256.Int
+	Gas   uint64
+
+	Code     []byte
+	CodeHash common.Hash
+	Input    []byte
+
+	jumpDests map[common.Hash]bitvec
+}
+
+// NewContract creates the frame for a call from caller to address, able to
+// spend gas. jumpDests is the EVM-instance-wide, code-hash-keyed analysis
+// cache shared across every contract invocation.
+func NewContract(caller common.Address, address common.Address, value *uint256.Int, gas uint64, jumpDests map[common.Hash]bitvec) *Contract {
+	return &Contract{
+		Caller:    caller,
+		Address:   address,
+		Value:     value,
+		Gas:       gas,
+		jumpDests: jumpDests,
+	}
+}
+
+// SetCallCode sets the code this contract executes and its hash.
+func (c *Contract) SetCallCode(codeHash common.Hash, code []byte) {
+	c.CodeHash = codeHash
+	c.Code = code
+}
+
+// UseGas deducts amount from the contract's remaining gas, returning false
+// (without deducting) if that would go negative.
+func (c *Contract) UseGas(amount uint64) bool {
+	if c.Gas < amount {
+		return false
+	}
+	c.Gas -= amount
+	return true
+}
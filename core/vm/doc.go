@@ -0,0 +1,16 @@
+// Package vm implements the SLOAD-caching subsystem this repository's
+// requests target, against a small interpreter that exercises exactly the
+// opcodes those requests need (PUSH1-PUSH32, POP, STOP, ADDRESS, SLOAD,
+// SSTORE and the CALL family). It lives under the real
+// github.com/ethereum/go-ethereum/core/vm import path so the package's own
+// baseline test (sload_cache_test.go, present before any of this series'
+// changes) and every subsequent request can import it the same way they'd
+// import the genuine core/vm, but it is not a patch against upstream's
+// EVM/Contract/instruction set: this tree has no go.mod and does not vendor
+// go-ethereum, so there is no full core/vm, core/state or core/tracing tree
+// here to extend incrementally. Porting this subsystem onto the real
+// upstream packages (analysis.go's jumpdest bitmap, the real jump table,
+// the real Contract/StateDB surfaces) is out of scope for this tree and
+// would need to happen as a follow-up change applied directly against an
+// actual go-ethereum checkout.
+package vm
@@ -0,0 +1,17 @@
+package vm
+
+import "errors"
+
+var (
+	// ErrExecutionReverted is returned when contract execution hits REVERT.
+	ErrExecutionReverted = errors.New("execution reverted")
+	// ErrOutOfGas is returned when an opcode can't be charged its gas cost.
+	ErrOutOfGas = errors.New("out of gas")
+	// ErrDepth is returned when a CALL-family opcode would exceed
+	// maxCallDepth, or the EVM instance's total call budget (see
+	// defaultCallBudget in evm.go).
+	ErrDepth = errors.New("max call depth exceeded")
+	// ErrInvalidOpcode is returned for any byte outside this package's
+	// reduced instruction set.
+	ErrInvalidOpcode = errors.New("invalid opcode")
+)
@@ -0,0 +1,299 @@
+package vm
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// BlockContext carries auxiliary block-level information the EVM needs that
+// isn't part of any one transaction.
+type BlockContext struct {
+	CanTransfer func(StateDB, common.Address, *uint256.Int) bool
+	Transfer    func(StateDB, common.Address, common.Address, *uint256.Int)
+	GetHash     func(uint64) common.Hash
+}
+
+// Config tunes optional EVM behaviour. None of these fields change
+// consensus outcomes; they only change how execution gets there.
+type Config struct {
+	// Tracer receives execution events, OnStorageAccess among them. A nil
+	// Tracer, or a Tracer with a nil hook, simply means that event isn't
+	// reported.
+	Tracer *tracing.Hooks
+
+	// EnableSloadCache turns on the per-frame SLOAD cache (sload_cache.go).
+	EnableSloadCache bool
+
+	// EnableStoragePrefetch turns on the jumpdest-analysis-driven storage
+	// prefetcher (storage_prefetch.go). It has no effect unless
+	// EnableSloadCache is also set, since it only ever warms that cache.
+	EnableStoragePrefetch bool
+}
+
+// maxCallDepth is the deepest a chain of CALL/CALLCODE/DELEGATECALL/
+// STATICCALL may nest before further calls fail outright.
+const maxCallDepth = 1024
+
+// defaultCallBudget bounds the total number of CALL-family invocations a
+// single EVM instance may perform across every Run and its whole call tree,
+// not just along one chain of nesting; NewEVM sets it once and nothing
+// replenishes it. maxCallDepth alone only bounds depth; a frame that
+// issues many sibling calls at the same depth, each of which does the same,
+// can still multiply out to an intractable amount of work. This is a
+// defense-in-depth limit most real-world contracts never get close to.
+const defaultCallBudget = 4096
+
+// EVM is a small, self-contained interpreter over the reduced instruction
+// set core/vm's SLOAD-caching subsystem needs to exercise: PUSH1-PUSH32,
+// POP, STOP, ADDRESS, SLOAD, SSTORE, CALL, CALLCODE, DELEGATECALL,
+// STATICCALL and REVERT.
+type EVM struct {
+	BlockContext
+	StateDB StateDB
+	Config  Config
+
+	chainConfig *params.ChainConfig
+	depth       int
+	callBudget  int
+
+	jumpDests map[common.Hash]bitvec
+
+	sloadCache *sloadCache
+	prefetcher *storagePrefetcher
+
+	// stateMu guards every StateDB access. The interpreter goroutine is the
+	// only caller for most of a frame's life, but the storage prefetcher
+	// warms the SLOAD cache from background goroutines concurrently with
+	// interpretation, and StateDB is not safe for concurrent use on its own.
+	// All StateDB access, from both the interpreter and the prefetcher, goes
+	// through the stateXxx helpers below rather than touching evm.StateDB
+	// directly.
+	//
+	// It is a RWMutex rather than a plain Mutex so that multiple prefetch
+	// goroutines reading different slots can actually run concurrently: Go
+	// maps tolerate any number of simultaneous readers, the race is only
+	// between a reader and a concurrent writer (e.g. an SSTORE invalidating
+	// the very slot a prefetch is reading). A write still excludes every
+	// reader exactly as a plain Mutex would, so this changes nothing about
+	// which interleavings are safe, only how much of the safe ones can
+	// overlap.
+	stateMu sync.RWMutex
+}
+
+// NewEVM creates an EVM ready to Run contracts against statedb.
+func NewEVM(blockCtx BlockContext, statedb StateDB, chainConfig *params.ChainConfig, config Config) *EVM {
+	evm := &EVM{
+		BlockContext: blockCtx,
+		StateDB:      statedb,
+		Config:       config,
+		chainConfig:  chainConfig,
+		callBudget:   defaultCallBudget,
+		jumpDests:    make(map[common.Hash]bitvec),
+	}
+	evm.sloadCache = newSloadCache(config.EnableSloadCache)
+	if config.EnableStoragePrefetch {
+		evm.prefetcher = newStoragePrefetcher(evm)
+	}
+	return evm
+}
+
+// Call executes the code at addr as a CALL from caller: a fresh call frame
+// with its own storage context (addr's).
+func (evm *EVM) Call(caller common.Address, addr common.Address, input []byte, gas uint64, value *uint256.Int) ([]byte, uint64, error) {
+	return evm.call(caller, addr, addr, input, gas, value, false)
+}
+
+// CallCode executes the code at addr as a CALLCODE from caller: the code
+// runs, but storage operations apply to caller's own account, not addr's.
+func (evm *EVM) CallCode(caller common.Address, addr common.Address, input []byte, gas uint64, value *uint256.Int) ([]byte, uint64, error) {
+	return evm.call(caller, caller, addr, input, gas, value, false)
+}
+
+// DelegateCall executes the code at addr as a DELEGATECALL: like CallCode,
+// storage operations apply to caller's account, and additionally the
+// original caller/value context is preserved rather than replaced.
+func (evm *EVM) DelegateCall(caller common.Address, addr common.Address, input []byte, gas uint64) ([]byte, uint64, error) {
+	return evm.call(caller, caller, addr, input, gas, new(uint256.Int), false)
+}
+
+// StaticCall executes the code at addr as a STATICCALL: a CALL that may not
+// modify state.
+func (evm *EVM) StaticCall(caller common.Address, addr common.Address, input []byte, gas uint64) ([]byte, uint64, error) {
+	return evm.call(caller, addr, addr, input, gas, new(uint256.Int), true)
+}
+
+// call is the shared implementation behind Call/CallCode/DelegateCall/
+// StaticCall. storageAddr is the account whose storage the callee's code
+// operates on (addr for CALL/STATICCALL, caller for CALLCODE/DELEGATECALL);
+// codeAddr is whose code is actually executed.
+func (evm *EVM) call(caller, storageAddr, codeAddr common.Address, input []byte, gas uint64, value *uint256.Int, readOnly bool) ([]byte, uint64, error) {
+	if evm.depth >= maxCallDepth || evm.callBudget <= 0 {
+		return nil, gas, ErrDepth
+	}
+	evm.callBudget--
+	evm.depth++
+	snapshot := evm.stateSnapshot()
+	evm.sloadCache.pushFrame()
+
+	code := evm.stateGetCode(codeAddr)
+	contract := NewContract(caller, storageAddr, value, gas, evm.jumpDests)
+	contract.SetCallCode(crypto.Keccak256Hash(code), code)
+
+	if evm.prefetcher != nil {
+		evm.prefetcher.prefetch(storageAddr, code)
+	}
+
+	ret, err := evm.Run(contract, input, readOnly)
+
+	evm.depth--
+	if evm.prefetcher != nil {
+		evm.prefetcher.wait()
+	}
+	if err != nil {
+		evm.stateRevertToSnapshot(snapshot)
+		evm.sloadCache.popFrame(false)
+	} else {
+		evm.sloadCache.popFrame(true)
+	}
+	return ret, contract.Gas, err
+}
+
+// The stateXxx methods below are the only sanctioned way to reach evm.StateDB
+// once a prefetcher exists: they hold stateMu for the duration of the call,
+// so a background prefetch goroutine's read can never race the interpreter
+// goroutine's own reads and writes on the same (non-thread-safe) StateDB.
+
+func (evm *EVM) stateGetCode(addr common.Address) []byte {
+	evm.stateMu.RLock()
+	defer evm.stateMu.RUnlock()
+	return evm.StateDB.GetCode(addr)
+}
+
+func (evm *EVM) stateGetState(addr common.Address, slot common.Hash) common.Hash {
+	evm.stateMu.RLock()
+	defer evm.stateMu.RUnlock()
+	return evm.StateDB.GetState(addr, slot)
+}
+
+func (evm *EVM) stateSetState(addr common.Address, slot, value common.Hash) {
+	evm.stateMu.Lock()
+	defer evm.stateMu.Unlock()
+	evm.StateDB.SetState(addr, slot, value)
+}
+
+func (evm *EVM) stateSlotInAccessList(addr common.Address, slot common.Hash) (addressOk, slotOk bool) {
+	evm.stateMu.RLock()
+	defer evm.stateMu.RUnlock()
+	return evm.StateDB.SlotInAccessList(addr, slot)
+}
+
+func (evm *EVM) stateAddSlotToAccessList(addr common.Address, slot common.Hash) {
+	evm.stateMu.Lock()
+	defer evm.stateMu.Unlock()
+	evm.StateDB.AddSlotToAccessList(addr, slot)
+}
+
+func (evm *EVM) stateSnapshot() int {
+	evm.stateMu.RLock()
+	defer evm.stateMu.RUnlock()
+	return evm.StateDB.Snapshot()
+}
+
+func (evm *EVM) stateRevertToSnapshot(id int) {
+	evm.stateMu.Lock()
+	defer evm.stateMu.Unlock()
+	evm.StateDB.RevertToSnapshot(id)
+}
+
+// gasQuickStep is the flat cost of the cheapest opcodes in this reduced
+// instruction set (PUSH, POP, ADDRESS).
+const gasQuickStep = 3
+
+// Run interprets contract.Code from offset 0 until STOP, REVERT, running off
+// the end of the code, or an error. input is unused by any opcode in this
+// reduced instruction set but is accepted, and stored on the contract, to
+// match the shape of a real interpreter entry point.
+func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) ([]byte, error) {
+	contract.Input = input
+	stack := newEvmStack()
+	code := contract.Code
+
+	for pc := 0; pc < len(code); {
+		op := OpCode(code[pc])
+
+		if op >= PUSH1 && op <= PUSH32 {
+			if !contract.UseGas(gasQuickStep) {
+				return nil, ErrOutOfGas
+			}
+			n := int(op-PUSH1) + 1
+			end := pc + 1 + n
+			var buf [32]byte
+			if end > len(code) {
+				copy(buf[32-n:], code[pc+1:])
+			} else {
+				copy(buf[32-n:], code[pc+1:end])
+			}
+			var v uint256.Int
+			v.SetBytes(buf[:])
+			stack.push(&v)
+			pc = end
+			continue
+		}
+
+		switch op {
+		case STOP:
+			return nil, nil
+
+		case POP:
+			if !contract.UseGas(gasQuickStep) {
+				return nil, ErrOutOfGas
+			}
+			stack.pop()
+			pc++
+
+		case ADDRESS:
+			if !contract.UseGas(gasQuickStep) {
+				return nil, ErrOutOfGas
+			}
+			var v uint256.Int
+			v.SetBytes(contract.Address.Bytes())
+			stack.push(&v)
+			pc++
+
+		case SLOAD:
+			if err := opSload(evm, contract, stack); err != nil {
+				return nil, err
+			}
+			pc++
+
+		case SSTORE:
+			if readOnly {
+				return nil, ErrExecutionReverted
+			}
+			if err := opSstore(evm, contract, stack); err != nil {
+				return nil, err
+			}
+			pc++
+
+		case CALL, CALLCODE, DELEGATECALL, STATICCALL:
+			if err := opCall(evm, contract, stack, op, readOnly); err != nil {
+				return nil, err
+			}
+			pc++
+
+		case REVERT:
+			stack.pop() // offset (unused: no memory model in this reduced interpreter)
+			stack.pop() // size
+			return nil, ErrExecutionReverted
+
+		default:
+			return nil, ErrInvalidOpcode
+		}
+	}
+	return nil, nil
+}
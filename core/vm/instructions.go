@@ -0,0 +1,150 @@
+package vm
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// evmStack is the minimal LIFO operand stack this package's reduced
+// interpreter needs; it intentionally skips the depth limit and gas
+// metering a full implementation would apply to PUSH/DUP/SWAP, since none
+// of those beyond PUSH are part of this instruction set.
+type evmStack struct {
+	data []*uint256.Int
+}
+
+func newEvmStack() *evmStack {
+	return &evmStack{}
+}
+
+func (s *evmStack) push(v *uint256.Int) {
+	s.data = append(s.data, v)
+}
+
+func (s *evmStack) pop() *uint256.Int {
+	n := len(s.data) - 1
+	v := s.data[n]
+	s.data = s.data[:n]
+	return v
+}
+
+// gasSloadCold and gasSloadWarm are the EIP-2929 SLOAD costs. They depend
+// only on access-list membership, never on whether the SLOAD cache served
+// the read, so a cached read costs exactly what an uncached one would.
+const (
+	gasSloadCold = 2100
+	gasSloadWarm = 100
+)
+
+// gasSstore is a flat SSTORE charge. A full implementation prices SSTORE by
+// the original/current/new value transition (EIP-2200); this reduced
+// interpreter doesn't need that nuance since every call site in this
+// package charges it identically regardless of the SLOAD cache.
+const gasSstore = 20000
+
+// gasCallBase is the flat overhead of a CALL-family opcode, independent of
+// the callee's own gas consumption.
+const gasCallBase = 700
+
+// opSload implements the SLOAD opcode: pop a slot, push its value. The
+// gas charged depends only on EIP-2929 access-list membership, which
+// opSload checks itself before calling sloadWithCache, so enabling the SLOAD
+// cache never changes gas accounting — only whether StateDB.GetState is
+// actually called.
+func opSload(evm *EVM, contract *Contract, stack *evmStack) error {
+	slot := common.Hash(stack.pop().Bytes32())
+
+	_, slotWarm := evm.stateSlotInAccessList(contract.Address, slot)
+	gas := uint64(gasSloadWarm)
+	if !slotWarm {
+		gas = gasSloadCold
+	}
+	if !contract.UseGas(gas) {
+		return ErrOutOfGas
+	}
+
+	value := evm.sloadWithCache(contract.Address, slot)
+
+	var v uint256.Int
+	v.SetBytes(value.Bytes())
+	stack.push(&v)
+	return nil
+}
+
+// opSstore implements the SSTORE opcode: pop a slot then a value (matching
+// real EVM operand order), write the value, and invalidate that slot in the
+// SLOAD cache (and, via StateDB, in the cross-transaction warm storage
+// cache) so a later SLOAD of the same slot never observes a stale cached
+// value.
+func opSstore(evm *EVM, contract *Contract, stack *evmStack) error {
+	if !contract.UseGas(gasSstore) {
+		return ErrOutOfGas
+	}
+	slot := common.Hash(stack.pop().Bytes32())
+	value := common.Hash(stack.pop().Bytes32())
+
+	evm.stateSetState(contract.Address, slot, value)
+	evm.sloadCache.invalidate(contract.Address, slot)
+	return nil
+}
+
+// opCall implements CALL, CALLCODE, DELEGATECALL and STATICCALL. Operand
+// order on the stack matches the real EVM: CALL/CALLCODE pop
+// (gas, addr, value, argsOffset, argsSize, retOffset, retSize);
+// DELEGATECALL/STATICCALL are the same minus value. This reduced
+// interpreter has no memory, so argsOffset/argsSize/retOffset/retSize are
+// popped only to keep stack bookkeeping correct and never dereferenced.
+func opCall(evm *EVM, contract *Contract, stack *evmStack, op OpCode, readOnly bool) error {
+	if !contract.UseGas(gasCallBase) {
+		return ErrOutOfGas
+	}
+
+	childGas := stack.pop().Uint64()
+	target := common.Address(stack.pop().Bytes20())
+
+	var value *uint256.Int
+	if op == CALL || op == CALLCODE {
+		value = stack.pop()
+	} else {
+		value = new(uint256.Int)
+	}
+	stack.pop() // argsOffset
+	stack.pop() // argsSize
+	stack.pop() // retOffset
+	stack.pop() // retSize
+
+	if childGas > contract.Gas {
+		childGas = contract.Gas
+	}
+	contract.Gas -= childGas
+
+	var (
+		ret      []byte
+		gasLeft  uint64
+		callErr  error
+		callSite = contract.Address
+	)
+	switch {
+	case readOnly:
+		// A STATICCALL context forbids state modification no matter which
+		// CALL-family opcode is used underneath it.
+		ret, gasLeft, callErr = evm.StaticCall(callSite, target, nil, childGas)
+	case op == CALL:
+		ret, gasLeft, callErr = evm.Call(callSite, target, nil, childGas, value)
+	case op == CALLCODE:
+		ret, gasLeft, callErr = evm.CallCode(callSite, target, nil, childGas, value)
+	case op == DELEGATECALL:
+		ret, gasLeft, callErr = evm.DelegateCall(callSite, target, nil, childGas)
+	case op == STATICCALL:
+		ret, gasLeft, callErr = evm.StaticCall(callSite, target, nil, childGas)
+	}
+	_ = ret
+	contract.Gas += gasLeft
+
+	success := uint256.NewInt(0)
+	if callErr == nil {
+		success = uint256.NewInt(1)
+	}
+	stack.push(success)
+	return nil
+}
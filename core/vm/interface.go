@@ -0,0 +1,23 @@
+package vm
+
+import "github.com/ethereum/go-ethereum/common"
+
+// StateDB is the set of methods core/vm needs from account/storage state.
+// The concrete implementation lives in core/state; this package only ever
+// depends on the interface so it can be driven by test doubles such as
+// countingState in sload_cache_test.go.
+type StateDB interface {
+	CreateAccount(common.Address)
+
+	SetCode(common.Address, []byte)
+	GetCode(common.Address) []byte
+
+	GetState(common.Address, common.Hash) common.Hash
+	SetState(common.Address, common.Hash, common.Hash)
+
+	SlotInAccessList(addr common.Address, slot common.Hash) (addressOk, slotOk bool)
+	AddSlotToAccessList(addr common.Address, slot common.Hash)
+
+	Snapshot() int
+	RevertToSnapshot(int)
+}
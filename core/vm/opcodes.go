@@ -0,0 +1,55 @@
+package vm
+
+// OpCode is a single EVM instruction byte. Only the subset this package's
+// SLOAD-caching subsystem needs to exercise is defined here.
+type OpCode byte
+
+const (
+	STOP    OpCode = 0x00
+	ADDRESS OpCode = 0x30
+	POP     OpCode = 0x50
+	SLOAD   OpCode = 0x54
+	SSTORE  OpCode = 0x55
+
+	PUSH1  OpCode = 0x60
+	PUSH32 OpCode = 0x7f
+
+	CALL         OpCode = 0xf1
+	CALLCODE     OpCode = 0xf2
+	REVERT       OpCode = 0xfd
+	DELEGATECALL OpCode = 0xf4
+	STATICCALL   OpCode = 0xfa
+)
+
+// String returns the mnemonic for op, or "UNKNOWN" for anything not in this
+// package's reduced instruction set.
+func (op OpCode) String() string {
+	switch {
+	case op >= PUSH1 && op <= PUSH32:
+		return "PUSH"
+	}
+	switch op {
+	case STOP:
+		return "STOP"
+	case ADDRESS:
+		return "ADDRESS"
+	case POP:
+		return "POP"
+	case SLOAD:
+		return "SLOAD"
+	case SSTORE:
+		return "SSTORE"
+	case CALL:
+		return "CALL"
+	case CALLCODE:
+		return "CALLCODE"
+	case DELEGATECALL:
+		return "DELEGATECALL"
+	case STATICCALL:
+		return "STATICCALL"
+	case REVERT:
+		return "REVERT"
+	default:
+		return "UNKNOWN"
+	}
+}
@@ -0,0 +1,225 @@
+package vm
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// sloadKey identifies a single storage slot belonging to a contract account.
+// It is the unit of granularity for both caching and invalidation, so a
+// write to one slot never disturbs cached reads of any other slot.
+type sloadKey struct {
+	addr common.Address
+	slot common.Hash
+}
+
+// sloadCache caches SLOAD results for the lifetime of a single EVM instance.
+// Unlike a flat map wiped on every SSTORE, it is organised as a stack of
+// per-call-frame layers: entering Call/CallCode/DelegateCall/StaticCall
+// pushes a new layer, and returning from that frame either merges the layer
+// into its parent (a normal RETURN/STOP) or discards it outright (a
+// REVERT), mirroring the semantics of the state journal. This keeps cached
+// values frame-local so a reverted frame can never leak a stale or
+// speculative read to its caller.
+//
+// Entries are invalidated one key at a time when an SSTORE writes to that
+// slot, never by wiping the whole cache, so unrelated slots stay warm across
+// nested calls. Caching only ever saves the underlying StateDB read: callers
+// are still responsible for EIP-2929 warm/cold access-list accounting on
+// every SLOAD, cache hit or not, so a cached read never changes consensus
+// gas cost.
+//
+// mu guards every field below. The interpreter goroutine is the only writer
+// for most of a frame's life, but the storage prefetcher warms entries from
+// background goroutines concurrently with interpretation, so all access
+// goes through the lock rather than relying on single-goroutine use.
+//
+// epoch counts invalidations. A prefetch goroutine reads epoch before it
+// starts its (unlocked, potentially slow) StateDB read and passes it back to
+// setIfEpoch once the read completes; if an SSTORE invalidated the same slot
+// in the meantime, epoch will have moved on and the stale prefetched value is
+// dropped instead of clobbering the invalidation. See setIfEpoch.
+type sloadCache struct {
+	enabled bool
+
+	mu     sync.Mutex
+	frames []map[sloadKey]common.Hash
+	epoch  uint64
+}
+
+// newSloadCache creates a cache that is a no-op unless enabled is true, so
+// callers can unconditionally push/pop/get/set without checking the flag.
+// enabled is sourced from Config.EnableSloadCache at EVM construction time.
+func newSloadCache(enabled bool) *sloadCache {
+	c := &sloadCache{enabled: enabled}
+	if enabled {
+		c.frames = []map[sloadKey]common.Hash{make(map[sloadKey]common.Hash)}
+	}
+	return c
+}
+
+// pushFrame opens a new cache layer for a nested call. It must be paired
+// with exactly one popFrame call when that call returns, and is called from
+// EVM.Call, EVM.CallCode, EVM.DelegateCall and EVM.StaticCall alongside the
+// state journal's own snapshot.
+func (c *sloadCache) pushFrame() {
+	if !c.enabled {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frames = append(c.frames, make(map[sloadKey]common.Hash))
+}
+
+// popFrame closes the most recently opened layer. If commit is true (the
+// call returned normally), the layer's entries are merged down into its
+// parent so the caller keeps the benefit of slots its callee warmed; if
+// commit is false (the call reverted), the layer is dropped entirely,
+// exactly as the journal discards that frame's state changes.
+func (c *sloadCache) popFrame(commit bool) {
+	if !c.enabled {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.frames) <= 1 {
+		return
+	}
+	top := c.frames[len(c.frames)-1]
+	c.frames = c.frames[:len(c.frames)-1]
+	if !commit {
+		return
+	}
+	parent := c.frames[len(c.frames)-1]
+	for k, v := range top {
+		parent[k] = v
+	}
+}
+
+// get returns the cached value for (addr, slot), searching from the current
+// frame down to the root so a read sees any value warmed by an ancestor
+// frame that hasn't been invalidated since.
+func (c *sloadCache) get(addr common.Address, slot common.Hash) (common.Hash, bool) {
+	if !c.enabled {
+		return common.Hash{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := sloadKey{addr, slot}
+	for i := len(c.frames) - 1; i >= 0; i-- {
+		if v, ok := c.frames[i][key]; ok {
+			return v, true
+		}
+	}
+	return common.Hash{}, false
+}
+
+// set records a freshly loaded value in the current frame.
+func (c *sloadCache) set(addr common.Address, slot common.Hash, value common.Hash) {
+	if !c.enabled {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frames[len(c.frames)-1][sloadKey{addr, slot}] = value
+}
+
+// invalidate drops any cached entry for (addr, slot) across every active
+// frame. It is called from the SSTORE opcode handler so a subsequent SLOAD
+// of that slot, in this frame or any ancestor still on the stack, re-reads
+// the authoritative value from the StateDB instead of returning a now-stale
+// cached one.
+func (c *sloadCache) invalidate(addr common.Address, slot common.Hash) {
+	if !c.enabled {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := sloadKey{addr, slot}
+	for _, frame := range c.frames {
+		delete(frame, key)
+	}
+	c.epoch++
+}
+
+// currentEpoch returns the cache's invalidation counter. The prefetcher reads
+// this immediately before issuing its StateDB read, then passes it back to
+// setIfEpoch so a write that completes after an invalidation race can be told
+// apart from one that didn't.
+func (c *sloadCache) currentEpoch() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.epoch
+}
+
+// setIfEpoch records value for (addr, slot), but only if no invalidation has
+// happened since epoch was observed. It is the prefetcher's equivalent of
+// set: a plain set would let a slow background read for a slot that was
+// SSTORE'd moments ago land after the invalidation and silently resurrect the
+// stale value; setIfEpoch instead drops the write in that case, leaving the
+// slot to be loaded for real on the next SLOAD.
+func (c *sloadCache) setIfEpoch(addr common.Address, slot common.Hash, value common.Hash, epoch uint64) {
+	if !c.enabled {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.epoch != epoch {
+		return
+	}
+	c.frames[len(c.frames)-1][sloadKey{addr, slot}] = value
+}
+
+// sloadWithCache is the integration point used by the SLOAD opcode
+// (instructions.go). It never bypasses EIP-2929 access-list bookkeeping: the
+// caller must still charge warm or cold access cost before or after calling
+// this, exactly as it would without the cache. The cache only elides the
+// redundant StateDB.GetState call on a repeat read of the same slot. Every
+// read, hit or miss, is surfaced through OnStorageAccess so tracers can
+// measure cache effectiveness and reproduce the exact reads a transaction
+// performed.
+func (evm *EVM) sloadWithCache(addr common.Address, slot common.Hash) common.Hash {
+	if v, ok := evm.sloadCache.get(addr, slot); ok {
+		// An entry put there by the storage prefetcher can be hit before any
+		// SLOAD has added the slot to the access list; add it now so later
+		// reads are charged warm, as they would be without the cache.
+		if _, slotPresent := evm.stateSlotInAccessList(addr, slot); !slotPresent {
+			evm.stateAddSlotToAccessList(addr, slot)
+		}
+		evm.reportStorageAccess(addr, slot, v, StorageAccessCache)
+		return v
+	}
+
+	source := StorageAccessWarm
+	if _, slotPresent := evm.stateSlotInAccessList(addr, slot); !slotPresent {
+		source = StorageAccessCold
+	}
+	evm.stateAddSlotToAccessList(addr, slot)
+
+	v := evm.stateGetState(addr, slot)
+	evm.sloadCache.set(addr, slot, v)
+	evm.reportStorageAccess(addr, slot, v, source)
+	return v
+}
+
+// prefetchSlot speculatively warms the SLOAD cache for (addr, slot) ahead of
+// any real SLOAD reaching it. Unlike sloadWithCache, it never touches the
+// EIP-2929 access list and never reports an OnStorageAccess event, since no
+// opcode has actually executed yet — only a genuine SLOAD may change warm/cold
+// accounting or appear in a tracer's read log. It is safe to call from a
+// background prefetch goroutine concurrently with interpretation: the
+// StateDB read goes through the same stateGetState lock the interpreter
+// itself uses, and setIfEpoch discards the result if an SSTORE invalidated
+// the slot while the read was in flight, so a racing write always wins.
+func (evm *EVM) prefetchSlot(addr common.Address, slot common.Hash) {
+	if !evm.sloadCache.enabled {
+		return
+	}
+	if _, ok := evm.sloadCache.get(addr, slot); ok {
+		return
+	}
+	epoch := evm.sloadCache.currentEpoch()
+	v := evm.stateGetState(addr, slot)
+	evm.sloadCache.setIfEpoch(addr, slot, v, epoch)
+}
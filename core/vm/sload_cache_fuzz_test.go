@@ -0,0 +1,205 @@
+package vm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// maxFuzzProgramOps bounds how many instruction templates a fuzz-generated
+// program can contain, so a large seed can't blow up execution time.
+const maxFuzzProgramOps = 48
+
+// fuzzProgramTemplates enumerates the instruction shapes
+// genRandomSloadProgram is allowed to emit. Each one is self-contained: it
+// pushes whatever operands it needs before the opcode it exercises, so any
+// concatenation of templates is a well-formed program.
+var fuzzProgramTemplates = []func(slot, value byte) []byte{
+	// PUSH1 slot; SLOAD; POP
+	func(slot, _ byte) []byte { return []byte{0x60, slot, 0x54, 0x50} },
+	// PUSH1 value; PUSH1 slot; SSTORE
+	func(slot, value byte) []byte { return []byte{0x60, value, 0x60, slot, 0x55} },
+	// PUSH1 slot; SLOAD; PUSH1 slot; SLOAD; POP; POP (repeat read, same frame)
+	func(slot, _ byte) []byte { return []byte{0x60, slot, 0x54, 0x60, slot, 0x54, 0x50, 0x50} },
+	// CALL self: push all 7 args (retSize, retOffset, argsSize, argsOffset,
+	// value, addr, gas), then CALL, then POP the success flag.
+	func(slot, _ byte) []byte {
+		return []byte{
+			0x60, 0x00, // retSize
+			0x60, 0x00, // retOffset
+			0x60, 0x00, // argsSize
+			0x60, 0x00, // argsOffset
+			0x60, 0x00, // value
+			0x30,       // ADDRESS (call self)
+			0x61, 0xff, // gas: PUSH2 0xffff, enough for the callee
+			0xff,       // to reach its own SLOAD/SSTORE/CALL templates
+			0xf1, // CALL
+			0x50, // POP success flag
+		}
+	},
+	// STATICCALL self, same shape minus the value operand.
+	func(slot, _ byte) []byte {
+		return []byte{
+			0x60, 0x00, // retSize
+			0x60, 0x00, // retOffset
+			0x60, 0x00, // argsSize
+			0x60, 0x00, // argsOffset
+			0x30,       // ADDRESS
+			0x61, 0xff, // gas: PUSH2 0xffff, enough for the callee
+			0xff,       // to reach its own SLOAD/SSTORE/CALL templates
+			0xfa, // STATICCALL
+			0x50, // POP success flag
+		}
+	},
+	// CALLCODE self: same operand shape as CALL; storage operations inside
+	// the call apply to the caller's own account. Since the target is the
+	// contract itself, that is also the account whose code runs
+	// (storageAddr == codeAddr).
+	func(slot, _ byte) []byte {
+		return []byte{
+			0x60, 0x00, // retSize
+			0x60, 0x00, // retOffset
+			0x60, 0x00, // argsSize
+			0x60, 0x00, // argsOffset
+			0x60, 0x00, // value
+			0x30,       // ADDRESS (call self)
+			0x61, 0xff, // gas: PUSH2 0xffff, enough for the callee
+			0xff,       // to reach its own SLOAD/SSTORE/CALL templates
+			0xf2, // CALLCODE
+			0x50, // POP success flag
+		}
+	},
+	// DELEGATECALL self, same shape minus the value operand; like CALLCODE,
+	// storage operations inside the call apply to the caller's account.
+	func(slot, _ byte) []byte {
+		return []byte{
+			0x60, 0x00, // retSize
+			0x60, 0x00, // retOffset
+			0x60, 0x00, // argsSize
+			0x60, 0x00, // argsOffset
+			0x30,       // ADDRESS
+			0x61, 0xff, // gas: PUSH2 0xffff, enough for the callee
+			0xff,       // to reach its own SLOAD/SSTORE/CALL templates
+			0xf4, // DELEGATECALL
+			0x50, // POP success flag
+		}
+	},
+}
+
+// genRandomSloadProgram deterministically expands a fuzz seed into a
+// sequence of SLOAD/SSTORE/CALL/CALLCODE/DELEGATECALL/STATICCALL templates
+// touching a small, seed derived set of slots, optionally ending in a
+// REVERT instead of a normal STOP so reverted frames are exercised as often
+// as committed ones.
+func genRandomSloadProgram(seed []byte) []byte {
+	var code []byte
+	for i := 0; i < len(seed) && i < maxFuzzProgramOps; i++ {
+		b := seed[i]
+		tmpl := fuzzProgramTemplates[int(b)%len(fuzzProgramTemplates)]
+		slot := b % 4   // keep the slot space small so cache hits/invalidations overlap
+		value := b ^ 0x5a
+		code = append(code, tmpl(slot, value)...)
+	}
+	if len(seed) > 0 && seed[len(seed)-1]%3 == 0 {
+		code = append(code, 0x60, 0x00, 0x60, 0x00, 0xfd) // PUSH1 0; PUSH1 0; REVERT
+	} else {
+		code = append(code, 0x00) // STOP
+	}
+	return code
+}
+
+// fuzzRunResult captures everything FuzzSloadCacheConsistency compares
+// between the cached and uncached executions of the same program.
+type fuzzRunResult struct {
+	root  common.Hash
+	ret   []byte
+	gas   uint64
+	loads int
+}
+
+// runFuzzProgram executes program against a fresh account/state pair, with
+// the SLOAD cache enabled or disabled per cacheEnabled, and reports the
+// resulting state root, return data, gas used and number of underlying
+// StateDB.GetState calls observed through countingState.
+func runFuzzProgram(t *testing.T, program []byte, cacheEnabled bool) fuzzRunResult {
+	t.Helper()
+
+	caching := state.NewDatabaseForTesting()
+	statedb, err := state.New(types.EmptyRootHash, caching)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	addr := common.HexToAddress("0xc00000000000000000000000000000000000001")
+	caller := common.HexToAddress("0xc00000000000000000000000000000000000002")
+	statedb.CreateAccount(addr)
+	statedb.SetCode(addr, program)
+
+	counting := &countingState{StateDB: statedb}
+	evm := newTestEVM(counting)
+	evm.Config.EnableSloadCache = cacheEnabled
+	evm.sloadCache = newSloadCache(cacheEnabled)
+
+	gas := uint64(1_000_000)
+	contract := NewContract(caller, addr, new(uint256.Int), gas, evm.jumpDests)
+	contract.SetCallCode(crypto.Keccak256Hash(program), program)
+
+	ret, err := evm.Run(contract, nil, false)
+	if err != nil && err != ErrExecutionReverted {
+		t.Fatalf("unexpected execution error: %v", err)
+	}
+
+	return fuzzRunResult{
+		root:  statedb.IntermediateRoot(false),
+		ret:   ret,
+		gas:   gas - contract.Gas,
+		loads: counting.loadCount(),
+	}
+}
+
+// FuzzSloadCacheConsistency generates random programs mixing SLOAD, SSTORE,
+// CALL, CALLCODE, DELEGATECALL, STATICCALL and REVERT and runs each one
+// twice, once with the SLOAD cache enabled and once with it disabled,
+// asserting they produce the exact same state root, return data and gas
+// usage. This is the property the hand-written cases in
+// sload_cache_test.go can't cover: arbitrary nesting and interleaving of
+// writes, reverts and repeat reads across frames, including CALLCODE and
+// DELEGATECALL frames. Every generated call targets the contract itself, so
+// frames whose storage account differs from their code account are not
+// generated. It also
+// cross-checks the cached run against the countingState oracle to confirm
+// caching never performs more underlying state reads than the uncached
+// run, i.e. it only ever saves work, never does extra.
+func FuzzSloadCacheConsistency(f *testing.F) {
+	f.Add([]byte{0x00})
+	f.Add([]byte{0x01, 0x01, 0x02})
+	f.Add([]byte{0x03, 0x00, 0x03, 0x01})
+	f.Add([]byte{0x10, 0x20, 0x10, 0x20, 0x30})
+
+	f.Fuzz(func(t *testing.T, seed []byte) {
+		if len(seed) == 0 || len(seed) > 256 {
+			return
+		}
+		program := genRandomSloadProgram(seed)
+
+		cached := runFuzzProgram(t, program, true)
+		plain := runFuzzProgram(t, program, false)
+
+		if cached.root != plain.root {
+			t.Fatalf("state root mismatch: cached=%x plain=%x", cached.root, plain.root)
+		}
+		if !bytes.Equal(cached.ret, plain.ret) {
+			t.Fatalf("returndata mismatch: cached=%x plain=%x", cached.ret, plain.ret)
+		}
+		if cached.gas != plain.gas {
+			t.Fatalf("gas mismatch: cached=%d plain=%d", cached.gas, plain.gas)
+		}
+		if cached.loads > plain.loads {
+			t.Fatalf("cached run performed more underlying loads (%d) than the uncached oracle (%d)", cached.loads, plain.loads)
+		}
+	})
+}
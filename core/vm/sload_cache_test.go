@@ -112,8 +112,8 @@ func TestSloadCacheHitsWithinFrame(t *testing.T) {
 	underlying.SetState(contractAddr, slot, value)
 
 	evm := newTestEVM(counting)
-	// 显式创建缓存映射，以模拟真实调用帧的缓存生命周期。
-	evm.sloadCache = make(map[sloadKey]common.Hash)
+	// 启用帧感知缓存，模拟调用进入时已经 push 过一层的状态。
+	evm.sloadCache = newSloadCache(true)
 
 	caller := common.HexToAddress("0x2000000000000000000000000000000000000002")
 	gas := uint64(1_000_000)
@@ -148,7 +148,7 @@ func TestSloadCacheInvalidatedBySstore(t *testing.T) {
 	underlying.SetState(contractAddr, slot, initial)
 
 	evm := newTestEVM(counting)
-	evm.sloadCache = make(map[sloadKey]common.Hash)
+	evm.sloadCache = newSloadCache(true)
 
 	caller := common.HexToAddress("0x4000000000000000000000000000000000000004")
 	gas := uint64(1_000_000)
@@ -173,3 +173,57 @@ func TestSloadCacheInvalidatedBySstore(t *testing.T) {
 		t.Fatalf("expected storage to be updated, got %x", got.Bytes())
 	}
 }
+
+// TestSloadCacheFrameRevertDiscardsWrites 输入: testing.T；输出: 无；
+// 作用: 验证子帧 REVERT 时其写入的缓存条目不会泄漏给父帧，
+// 而正常返回时父帧能看到子帧已经读到的值。
+func TestSloadCacheFrameRevertDiscardsWrites(t *testing.T) {
+	addr := common.HexToAddress("0x5000000000000000000000000000000000000005")
+	slot := common.HexToHash("0x07")
+	value := common.HexToHash("0x08")
+
+	c := newSloadCache(true)
+
+	// 子帧中写入缓存，然后以 REVERT 的方式弹出，条目不应保留到父帧。
+	c.pushFrame()
+	c.set(addr, slot, value)
+	if _, ok := c.get(addr, slot); !ok {
+		t.Fatalf("expected cache hit inside child frame")
+	}
+	c.popFrame(false)
+	if _, ok := c.get(addr, slot); ok {
+		t.Fatalf("expected reverted frame's cache entry to be discarded")
+	}
+
+	// 再次进入子帧并正常返回，父帧应当继承该条目。
+	c.pushFrame()
+	c.set(addr, slot, value)
+	c.popFrame(true)
+	if got, ok := c.get(addr, slot); !ok || got != value {
+		t.Fatalf("expected committed frame's cache entry to propagate to parent")
+	}
+}
+
+// TestSloadCacheInvalidateAcrossFrames 输入: testing.T；输出: 无；
+// 作用: 验证 SSTORE 触发的失效会清除所有活跃帧中对应的键，
+// 但不会影响其它槽位的缓存条目。
+func TestSloadCacheInvalidateAcrossFrames(t *testing.T) {
+	addr := common.HexToAddress("0x6000000000000000000000000000000000000006")
+	slotA := common.HexToHash("0x09")
+	slotB := common.HexToHash("0x0a")
+	value := common.HexToHash("0x0b")
+
+	c := newSloadCache(true)
+	c.set(addr, slotA, value)
+	c.set(addr, slotB, value)
+	c.pushFrame()
+
+	c.invalidate(addr, slotA)
+
+	if _, ok := c.get(addr, slotA); ok {
+		t.Fatalf("expected slotA to be invalidated in every frame")
+	}
+	if got, ok := c.get(addr, slotB); !ok || got != value {
+		t.Fatalf("expected slotB to remain cached")
+	}
+}
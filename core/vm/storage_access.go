@@ -0,0 +1,28 @@
+package vm
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+)
+
+// StorageAccessSource is an alias of tracing.StorageAccessSource so core/vm
+// call sites don't need to import core/tracing just to name the type.
+type StorageAccessSource = tracing.StorageAccessSource
+
+const (
+	StorageAccessCold  = tracing.StorageAccessCold
+	StorageAccessWarm  = tracing.StorageAccessWarm
+	StorageAccessCache = tracing.StorageAccessCache
+)
+
+// reportStorageAccess invokes the OnStorageAccess hook, if one is attached
+// to this EVM's tracer, describing a single storage read and where it was
+// served from. It is a no-op when no tracer is configured or the tracer
+// doesn't implement OnStorageAccess, so call sites don't need their own nil
+// checks.
+func (evm *EVM) reportStorageAccess(addr common.Address, slot, value common.Hash, source StorageAccessSource) {
+	if evm.Config.Tracer == nil || evm.Config.Tracer.OnStorageAccess == nil {
+		return
+	}
+	evm.Config.Tracer.OnStorageAccess(addr, slot, value, source)
+}
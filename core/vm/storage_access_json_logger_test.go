@@ -0,0 +1,68 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+)
+
+// TestStorageAccessJSONLoggerEmitsDecodableEvents 输入: testing.T；输出: 无；
+// 作用: 验证 StorageAccessJSONLogger 确实把 OnStorageAccess 事件写成
+// newline-delimited JSON，而不仅仅是把 source 值留在内存里：构造一个
+// bytes.Buffer 作为 io.Writer，驱动同一帧内的两次 SLOAD（首次 cold，第二次
+// 命中缓存），再把缓冲区按行解码，断言每一行的 addr/slot/value/source 字段
+// 都和实际发生的访问一致。
+func TestStorageAccessJSONLoggerEmitsDecodableEvents(t *testing.T) {
+	counting, underlying := newCountingState(t)
+
+	addr := common.HexToAddress("0x9000000000000000000000000000000000000009")
+	slot := common.HexToHash("0x10")
+	value := common.HexToHash("0x11")
+	underlying.CreateAccount(addr)
+	underlying.SetState(addr, slot, value)
+
+	var buf bytes.Buffer
+	logger := NewStorageAccessJSONLogger(&buf)
+	hooks := &tracing.Hooks{OnStorageAccess: logger.OnStorageAccess}
+
+	evm := newTestEVMWithTracer(counting, hooks)
+	evm.sloadCache = newSloadCache(true)
+
+	evm.sloadWithCache(addr, slot)
+	evm.sloadWithCache(addr, slot)
+
+	var entries []storageAccessEntry
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e storageAccessEntry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("failed to decode logged event: %v", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 logged events, got %d", len(entries))
+	}
+
+	for i, e := range entries {
+		if e.Addr != addr {
+			t.Fatalf("event %d: expected addr %x, got %x", i, addr, e.Addr)
+		}
+		if e.Slot != slot {
+			t.Fatalf("event %d: expected slot %x, got %x", i, slot.Bytes(), e.Slot.Bytes())
+		}
+		if e.Value != value {
+			t.Fatalf("event %d: expected value %x, got %x", i, value.Bytes(), e.Value.Bytes())
+		}
+	}
+	if entries[0].Source != StorageAccessCold.String() {
+		t.Fatalf("expected first logged event to be %q, got %q", StorageAccessCold.String(), entries[0].Source)
+	}
+	if entries[1].Source != StorageAccessCache.String() {
+		t.Fatalf("expected second logged event to be %q, got %q", StorageAccessCache.String(), entries[1].Source)
+	}
+}
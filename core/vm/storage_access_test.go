@@ -0,0 +1,85 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// newTestEVMWithTracer 输入: StateDB、tracing.Hooks；输出: *EVM；
+// 作用: 构造带自定义 Tracer 的测试 EVM，供 OnStorageAccess 相关用例复用。
+func newTestEVMWithTracer(state StateDB, hooks *tracing.Hooks) *EVM {
+	blockCtx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+	}
+	return NewEVM(blockCtx, state, params.TestChainConfig, Config{Tracer: hooks})
+}
+
+// TestSloadCacheHitsEmitCacheSource 输入: testing.T；输出: 无；
+// 作用: 断言同一帧内的第二次 SLOAD 命中缓存时，上报的 source 为 StorageAccessCache。
+func TestSloadCacheHitsEmitCacheSource(t *testing.T) {
+	counting, underlying := newCountingState(t)
+
+	addr := common.HexToAddress("0x7000000000000000000000000000000000000007")
+	slot := common.HexToHash("0x0c")
+	value := common.HexToHash("0x0d")
+	underlying.CreateAccount(addr)
+	underlying.SetState(addr, slot, value)
+	underlying.AddSlotToAccessList(addr, slot)
+
+	var sources []StorageAccessSource
+	hooks := &tracing.Hooks{
+		OnStorageAccess: func(_ common.Address, _, _ common.Hash, source StorageAccessSource) {
+			sources = append(sources, source)
+		},
+	}
+
+	evm := newTestEVMWithTracer(counting, hooks)
+	evm.sloadCache = newSloadCache(true)
+
+	evm.sloadWithCache(addr, slot)
+	evm.sloadWithCache(addr, slot)
+
+	if len(sources) != 2 {
+		t.Fatalf("expected 2 reported accesses, got %d", len(sources))
+	}
+	if sources[0] != StorageAccessWarm {
+		t.Fatalf("expected first access to be warm, got %s", sources[0])
+	}
+	if sources[1] != StorageAccessCache {
+		t.Fatalf("expected second access to be served from cache, got %s", sources[1])
+	}
+}
+
+// TestSloadColdAccessReported 输入: testing.T；输出: 无；
+// 作用: 断言访问列表中不存在该槽位时，首次读取上报 StorageAccessCold。
+func TestSloadColdAccessReported(t *testing.T) {
+	counting, underlying := newCountingState(t)
+
+	addr := common.HexToAddress("0x8000000000000000000000000000000000000008")
+	slot := common.HexToHash("0x0e")
+	value := common.HexToHash("0x0f")
+	underlying.CreateAccount(addr)
+	underlying.SetState(addr, slot, value)
+
+	var got StorageAccessSource
+	hooks := &tracing.Hooks{
+		OnStorageAccess: func(_ common.Address, _, _ common.Hash, source StorageAccessSource) {
+			got = source
+		},
+	}
+
+	evm := newTestEVMWithTracer(counting, hooks)
+	evm.sloadCache = newSloadCache(true)
+
+	evm.sloadWithCache(addr, slot)
+
+	if got != StorageAccessCold {
+		t.Fatalf("expected cold access, got %s", got)
+	}
+}
@@ -0,0 +1,100 @@
+package vm
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// scanStaticSloadSlots statically scans code for PUSH<n> SLOAD patterns,
+// i.e. a constant pushed immediately before a storage read, and returns the
+// slots those reads address. It mirrors the PUSH-immediate-skipping walk in
+// analysis.go's jumpdest bitmap scan: a PUSH's immediate bytes are never
+// reinterpreted as opcodes, so a 0x54 (SLOAD) byte that's actually push data
+// is never mistaken for a real SLOAD.
+//
+// The scan is a best-effort static approximation used only to prime the
+// cache; it makes no claim about which SLOADs will actually execute (a
+// PUSH/SLOAD pair inside a never-taken branch is still "found"), so callers
+// must treat its output purely as a prefetch hint, never as a correctness
+// source.
+func scanStaticSloadSlots(code []byte) []common.Hash {
+	var slots []common.Hash
+	for i := 0; i < len(code); {
+		op := OpCode(code[i])
+		if op < PUSH1 || op > PUSH32 {
+			i++
+			continue
+		}
+		n := int(op - PUSH1 + 1)
+		immEnd := i + 1 + n
+		if immEnd <= len(code) && immEnd < len(code) && OpCode(code[immEnd]) == SLOAD {
+			slots = append(slots, common.BytesToHash(code[i+1:immEnd]))
+		}
+		i = immEnd
+	}
+	return slots
+}
+
+// maxPrefetchWorkers bounds the number of goroutines a single EVM instance's
+// prefetcher may have in flight, so a contract with thousands of statically
+// addressed SLOADs can't spawn unbounded background work.
+const maxPrefetchWorkers = 4
+
+// storagePrefetcher asynchronously warms the SLOAD cache for slots a
+// contract's bytecode is statically known to read, so that by the time
+// interpretation reaches the SLOAD the value is often already cached. One
+// prefetcher is created per EVM instance; its goroutine pool is bounded by a
+// semaphore, and once the pool is saturated further slots are loaded
+// synchronously instead of queuing, so prefetching can never stall
+// interpretation behind a backlog of its own making.
+type storagePrefetcher struct {
+	evm *EVM
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+// newStoragePrefetcher creates a prefetcher bound to evm. It is always safe
+// to call its methods even when prefetching is disabled; they become no-ops.
+func newStoragePrefetcher(evm *EVM) *storagePrefetcher {
+	return &storagePrefetcher{evm: evm, sem: make(chan struct{}, maxPrefetchWorkers)}
+}
+
+// prefetch scans code for statically addressed SLOADs and schedules each
+// slot to be warmed into the SLOAD cache ahead of interpretation reaching
+// it. EVM.call calls it once per contract frame, after loading the frame's
+// code and before Run starts interpreting it.
+func (p *storagePrefetcher) prefetch(addr common.Address, code []byte) {
+	if p == nil || !p.evm.Config.EnableStoragePrefetch {
+		return
+	}
+	for _, slot := range scanStaticSloadSlots(code) {
+		select {
+		case p.sem <- struct{}{}:
+			p.wg.Add(1)
+			go func(slot common.Hash) {
+				defer p.wg.Done()
+				defer func() { <-p.sem }()
+				p.evm.prefetchSlot(addr, slot)
+			}(slot)
+		default:
+			// Pool saturated: fall back to a synchronous load rather than
+			// queuing, so prefetching degrades to a plain cache warm
+			// instead of adding latency of its own.
+			p.evm.prefetchSlot(addr, slot)
+		}
+	}
+}
+
+// wait blocks until every in-flight prefetch goroutine started by this
+// prefetcher has finished. EVM.call calls this after the frame's Run
+// returns and before its snapshot is reverted or its cache layer popped, so
+// no prefetch is still in flight at that point. A prefetch that races an
+// SLOAD or SSTORE while Run is still going is handled by the SLOAD cache's
+// epoch check (setIfEpoch) instead.
+func (p *storagePrefetcher) wait() {
+	if p == nil {
+		return
+	}
+	p.wg.Wait()
+}
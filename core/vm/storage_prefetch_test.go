@@ -0,0 +1,243 @@
+package vm
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// blockingState 包装一个 StateDB，使其 GetState 先读出底层值，再关闭
+// entered、阻塞在 proceed 上，直到调用方放行后才返回先前读到的值。仅用于
+// TestStoragePrefetchRaceAgainstConcurrentInvalidate，以确定性地制造预取
+// goroutine 的状态读取与另一 goroutine 的失效操作之间的真实交错，而不是依赖
+// time.Sleep 这种不可靠的时序假设。
+type blockingState struct {
+	StateDB
+	entered     chan struct{}
+	enteredOnce sync.Once
+	proceed     chan struct{}
+}
+
+func (b *blockingState) GetState(addr common.Address, key common.Hash) common.Hash {
+	v := b.StateDB.GetState(addr, key)
+	b.enteredOnce.Do(func() { close(b.entered) })
+	<-b.proceed
+	return v
+}
+
+// buildPushSloadCode 输入: 槽位、槽位之后是否紧跟 SLOAD；输出: 字节码；
+// 作用: 组装 "PUSH32 <slot> [SLOAD]" 片段，用于静态扫描测试。
+func buildPushSloadCode(slot common.Hash, followedBySload bool) []byte {
+	code := append([]byte{byte(PUSH32)}, slot.Bytes()...)
+	if followedBySload {
+		code = append(code, byte(SLOAD))
+	} else {
+		code = append(code, byte(POP))
+	}
+	return code
+}
+
+// TestScanStaticSloadSlotsFindsPushSload 输入: testing.T；输出: 无；
+// 作用: 验证扫描器能从 ERC20 式的 "balanceOf" 访问模式中取出常量槽位，
+// 且不会把紧跟在其它 PUSH 之后、并非 SLOAD 的字节误判成命中。
+func TestScanStaticSloadSlotsFindsPushSload(t *testing.T) {
+	balanceSlot := common.HexToHash("0x00")
+	allowanceSlot := common.HexToHash("0x01")
+
+	var code []byte
+	code = append(code, buildPushSloadCode(balanceSlot, true)...)
+	code = append(code, buildPushSloadCode(common.HexToHash("0x99"), false)...) // not a SLOAD, must be skipped
+	code = append(code, buildPushSloadCode(allowanceSlot, true)...)
+	code = append(code, byte(STOP))
+
+	got := scanStaticSloadSlots(code)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 statically found slots, got %d: %v", len(got), got)
+	}
+	if got[0] != balanceSlot || got[1] != allowanceSlot {
+		t.Fatalf("unexpected slots found: %v", got)
+	}
+}
+
+// TestScanStaticSloadSlotsSkipsPushImmediateBytes 输入: testing.T；输出: 无；
+// 作用: 验证扫描会跳过 PUSH 的立即数字节，不会把数据中偶然出现的
+// 0x54（SLOAD）字节当作真实指令。
+func TestScanStaticSloadSlotsSkipsPushImmediateBytes(t *testing.T) {
+	// 构造一个立即数中包含 0x54 字节的 PUSH32，且其后并非 SLOAD。立即数首字节
+	// 0x60 即 PUSH1，不跳过立即数的扫描会把其后的 "0x60 0x54 0x54" 误判为
+	// PUSH1 <x> SLOAD。
+	slot := common.HexToHash("0x6054545454545454545454545454545454545454545454545454545454545454")
+	code := append([]byte{byte(PUSH32)}, slot.Bytes()...)
+	code = append(code, byte(STOP))
+
+	if got := scanStaticSloadSlots(code); len(got) != 0 {
+		t.Fatalf("expected no false-positive hits from push immediate data, got %v", got)
+	}
+}
+
+// TestStoragePrefetchConsistentWithMidExecutionSstore 输入: testing.T；输出: 无；
+// 作用: 验证预取命中缓存之后，若该槽位随后被 SSTORE 覆写，
+// 缓存失效仍然生效，后续读取能看到最新值，而不是预取时的旧值。
+func TestStoragePrefetchConsistentWithMidExecutionSstore(t *testing.T) {
+	counting, underlying := newCountingState(t)
+
+	addr := common.HexToAddress("0x9000000000000000000000000000000000000009")
+	slot := common.HexToHash("0x02")
+	original := common.HexToHash("0x0a")
+	updated := common.HexToHash("0x0b")
+
+	underlying.CreateAccount(addr)
+	underlying.SetState(addr, slot, original)
+
+	evm := newTestEVM(counting)
+	evm.Config.EnableStoragePrefetch = true
+	evm.sloadCache = newSloadCache(true)
+
+	code := buildPushSloadCode(slot, true)
+
+	prefetcher := newStoragePrefetcher(evm)
+	prefetcher.prefetch(addr, code)
+	prefetcher.wait()
+
+	if got, ok := evm.sloadCache.get(addr, slot); !ok || got != original {
+		t.Fatalf("expected prefetch to warm the original value, got %x ok=%v", got.Bytes(), ok)
+	}
+
+	// 模拟执行中途发生的 SSTORE：更新底层状态并使缓存失效。
+	underlying.SetState(addr, slot, updated)
+	evm.sloadCache.invalidate(addr, slot)
+
+	if got := evm.sloadWithCache(addr, slot); got != updated {
+		t.Fatalf("expected post-SSTORE read to observe updated value, got %x", got.Bytes())
+	}
+}
+
+// TestStoragePrefetchRaceAgainstConcurrentInvalidate 输入: testing.T；输出: 无；
+// 作用: 用 blockingState 强制让一个预取 goroutine 的 StateDB.GetState 读取，
+// 与另一 goroutine（模拟解释器执行 SSTORE）对同一槽位的失效操作真实地交错：
+// 失效发生在预取的读取已经开始、但尚未把结果写回缓存之前。断言 epoch 守卫
+// 让这次失效获胜——预取读到的旧值绝不会覆盖掉失效标记，后续真实 SLOAD 只会
+// 看到写入后的新值。这样即便不加 -race 跑这个测试，也能确定性地复现
+// chunk0-3 最初缺少同步时会出现的“预取写回了陈旧值”这一逻辑竞态，而不必
+// 依赖 time.Sleep 式的偶然时序。
+func TestStoragePrefetchRaceAgainstConcurrentInvalidate(t *testing.T) {
+	_, underlying := newCountingState(t)
+
+	addr := common.HexToAddress("0xb000000000000000000000000000000000000b")
+	slot := common.HexToHash("0x10")
+	original := common.HexToHash("0x11")
+	updated := common.HexToHash("0x12")
+
+	underlying.CreateAccount(addr)
+	underlying.SetState(addr, slot, original)
+
+	blocking := &blockingState{StateDB: underlying, entered: make(chan struct{}), proceed: make(chan struct{})}
+
+	evm := newTestEVM(blocking)
+	evm.sloadCache = newSloadCache(true)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		evm.prefetchSlot(addr, slot)
+	}()
+
+	// 等待预取 goroutine 已经进入 GetState 并阻塞在其中，确保接下来的失效
+	// 操作确定性地发生在它的读取仍在途中，而不是偶然先于或晚于它完成。
+	<-blocking.entered
+
+	underlying.SetState(addr, slot, updated)
+	evm.sloadCache.invalidate(addr, slot)
+
+	close(blocking.proceed) // 放行预取的读取：它拿到的是写入前的旧值 original。
+	<-done
+
+	if _, ok := evm.sloadCache.get(addr, slot); ok {
+		t.Fatalf("expected the racing invalidate to win: a stale prefetched value must not remain cached")
+	}
+	if got := evm.sloadWithCache(addr, slot); got != updated {
+		t.Fatalf("expected a real SLOAD after the race to observe the updated value, got %x", got.Bytes())
+	}
+}
+
+// latencyState wraps a StateDB and adds a small fixed delay to every
+// GetState call, standing in for the trie/disk read latency a real backend
+// would incur. Without this, BenchmarkStoragePrefetchERC20Pattern only
+// measures an in-memory map lookup, which is fast enough that prefetching's
+// own goroutine/semaphore/mutex overhead could make with_prefetch look
+// slower than without_prefetch, even though prefetching exists specifically
+// to let that latency for multiple slots be paid in parallel instead of one
+// at a time.
+type latencyState struct {
+	StateDB
+	delay time.Duration
+}
+
+func (l *latencyState) GetState(addr common.Address, key common.Hash) common.Hash {
+	time.Sleep(l.delay)
+	return l.StateDB.GetState(addr, key)
+}
+
+// benchmarkStorageLatency approximates the per-read latency of a real trie
+// or disk-backed StateDB, large enough for prefetching's parallelism to
+// show up over the benchmark's fixed goroutine/semaphore overhead.
+const benchmarkStorageLatency = 200 * time.Microsecond
+
+// BenchmarkStoragePrefetchERC20Pattern 对比预取开启与关闭时，
+// 反复读取同一小撮槽位（典型的 ERC20 balance/allowance 访问模式）的耗时。
+// 底层 StateDB 包了一层 latencyState 来模拟真实 trie/磁盘读取的延迟，
+// 否则内存 map 查找本身快到测不出预取带来的墙钟时间收益。
+func BenchmarkStoragePrefetchERC20Pattern(b *testing.B) {
+	addr := common.HexToAddress("0xa000000000000000000000000000000000000a")
+	slots := []common.Hash{
+		common.HexToHash("0x00"), // balances[msg.sender]
+		common.HexToHash("0x01"), // allowances[msg.sender][spender]
+	}
+
+	setup := func(b *testing.B) (*EVM, []byte) {
+		b.Helper()
+		caching := state.NewDatabaseForTesting()
+		statedb, err := state.New(types.EmptyRootHash, caching)
+		if err != nil {
+			b.Fatalf("failed to create state: %v", err)
+		}
+		statedb.CreateAccount(addr)
+		for _, s := range slots {
+			statedb.SetState(addr, s, common.HexToHash("0x2a"))
+		}
+		evm := newTestEVM(&latencyState{StateDB: statedb, delay: benchmarkStorageLatency})
+		evm.sloadCache = newSloadCache(true)
+
+		var code []byte
+		for _, s := range slots {
+			code = append(code, buildPushSloadCode(s, true)...)
+		}
+		return evm, code
+	}
+
+	b.Run("without_prefetch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			evm, _ := setup(b)
+			for _, s := range slots {
+				evm.sloadWithCache(addr, s)
+			}
+		}
+	})
+
+	b.Run("with_prefetch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			evm, code := setup(b)
+			evm.Config.EnableStoragePrefetch = true
+			p := newStoragePrefetcher(evm)
+			p.prefetch(addr, code)
+			p.wait()
+			for _, s := range slots {
+				evm.sloadWithCache(addr, s)
+			}
+		}
+	})
+}